@@ -2,19 +2,125 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"../pkg/api"
+	"../pkg/cache"
+	"../pkg/geocode"
+	"../pkg/status"
 	"github.com/codingsince1985/geo-golang/openstreetmap"
+	"golang.org/x/time/rate"
 )
 
+// geoIPDBPathEnv points at a MaxMind GeoLite2-City.mmdb file. When unset, the GeoIP fallback
+// resolver step is skipped.
+const geoIPDBPathEnv = "GEOIP_DB_PATH"
+
+// geocodeWorkerCountEnv controls how many attractions are geocoded concurrently per request.
+const geocodeWorkerCountEnv = "GEOCODE_WORKER_COUNT"
+
+const defaultGeocodeWorkerCount = 4
+
+// geocodeJobTimeout bounds how long a single attraction can wait on the shared OSM rate limiter.
+// A job whose turn would come after the timeout fails at once with a rate-limit error rather than
+// holding up the whole request. The resolver call itself is not bounded by this timeout.
+const geocodeJobTimeout = 5 * time.Second
+
 // AttractionsResponse demonstrates the components involved for API responses.
 type AttractionsResponse struct {
-	SuccessfulAttractions []api.Attraction `json:"successful_attractions"`
-	FailedAttractions     []api.Attraction `json:"failed_attractions"`
-	ClosestNeighborhood   api.Neighborhood `json:"closest_neighborhood"`
+	SuccessfulAttractions []api.Attraction   `json:"successful_attractions"`
+	FailedAttractions     []FailedAttraction `json:"failed_attractions"`
+	ClosestNeighborhood   api.Neighborhood   `json:"closest_neighborhood"`
+}
+
+// FailedAttraction pairs an attraction that couldn't be resolved with why, so API clients can
+// distinguish an OSM rate-limit failure from a PostGIS failure without string-matching on an
+// error message.
+type FailedAttraction struct {
+	Attraction api.Attraction      `json:"attraction"`
+	Error      api.AttractionError `json:"error"`
+}
+
+// distanceCache is shared across requests; see pkg/cache for the DISTANCE_CACHE_* env vars that
+// choose its backend.
+var distanceCache cache.DistanceCache
+
+// statusTracker collects per-neighborhood and service-wide stats asynchronously; see pkg/status.
+var statusTracker *status.Tracker
+
+// geocodePool concurrently geocodes a request's attractions, rate-limited to OpenStreetMap's
+// documented 1 req/sec policy, falling back through the bundled city table and (if configured)
+// GeoIP when OSM can't place an address.
+var geocodePool *geocode.Pool
+
+// statusEventBufferSize bounds how many pending status events can queue before new ones are
+// dropped, so a burst of traffic can never make /attractions wait on status collection.
+const statusEventBufferSize = 1024
+
+// buildGeocodeResolverChain assembles the chain attractions are geocoded through: OpenStreetMap
+// first, then the bundled city table, then GeoIP (off the request's client IP) if GEOIP_DB_PATH
+// is configured.
+func buildGeocodeResolverChain() *geocode.ChainResolver {
+	resolvers := []geocode.Resolver{
+		geocode.NewOSMResolver(openstreetmap.Geocoder()),
+		geocode.NewCityTableResolver(geocode.TopCities),
+	}
+
+	dbPath := os.Getenv(geoIPDBPathEnv)
+	if dbPath == "" {
+		return geocode.NewChainResolver(resolvers...)
+	}
+
+	geoIPResolver, err := geocode.NewGeoIPResolver(dbPath)
+	if err != nil {
+		log.Printf("Unable to open GeoIP database at %s, skipping GeoIP fallback: %v\n", dbPath, err)
+		return geocode.NewChainResolver(resolvers...)
+	}
+
+	return geocode.NewChainResolver(append(resolvers, geoIPResolver)...)
+}
+
+// geocodeWorkerCount reads GEOCODE_WORKER_COUNT, defaulting to defaultGeocodeWorkerCount.
+func geocodeWorkerCount() int {
+	count, err := strconv.Atoi(os.Getenv(geocodeWorkerCountEnv))
+	if err != nil || count < 1 {
+		return defaultGeocodeWorkerCount
+	}
+
+	return count
+}
+
+// clientIPFromRequest prefers X-Forwarded-For (set by most proxies/load balancers) and falls
+// back to the request's RemoteAddr.
+func clientIPFromRequest(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// toAttractionError unwraps the *api.AttractionError the geocode pool returns, falling back to a
+// generic code if a non-pool error somehow shows up here.
+func toAttractionError(err error) api.AttractionError {
+	if attractionErr, ok := err.(*api.AttractionError); ok {
+		return *attractionErr
+	}
+
+	return api.AttractionError{Code: api.ErrCodeGeocodeFailed, Message: err.Error()}
 }
 
 func server() {
@@ -23,7 +129,21 @@ func server() {
 }
 
 func main() {
+	if err := api.InitSpatialIndex(); err != nil {
+		log.Printf("Unable to build spatial index, falling back to PostGIS-only lookups: %v\n", err)
+	}
+
+	distanceCache = cache.NewFromEnv()
+	statusTracker = status.NewTracker(statusEventBufferSize)
+
+	// OpenStreetMap's usage policy caps requests at 1/sec; share one limiter across the pool's
+	// workers so the whole batch -- not each worker independently -- respects it.
+	osmRateLimiter := rate.NewLimiter(rate.Limit(1), 1)
+	geocodePool = geocode.NewPool(buildGeocodeResolverChain(), geocodeWorkerCount(), osmRateLimiter)
+
 	http.HandleFunc("/attractions", handler)
+	http.HandleFunc("/status", statusTracker.Handler())
+	http.HandleFunc("/status/neighborhoods", statusTracker.NeighborhoodsHandler())
 	server()
 
 }
@@ -42,31 +162,72 @@ func handler(w http.ResponseWriter, r *http.Request) {
 
 	var responseAttractions AttractionsResponse
 
-	var neighborhoods []api.Neighborhood
-	geocoder := openstreetmap.Geocoder()
-	for _, attraction := range attractions {
-		attractionLocation, _ := attraction.GeocodeAttraction(geocoder)
+	clientIP := clientIPFromRequest(r)
+	queries := make([]geocode.Query, len(attractions))
+	for i, attraction := range attractions {
+		queries[i] = geocode.Query{Address: attraction.Name, ClientIP: clientIP}
+	}
+
+	geocodeResults := geocodePool.Resolve(r.Context(), queries, geocodeJobTimeout)
 
-		if attractionLocation == nil {
-			responseAttractions.FailedAttractions = append(responseAttractions.FailedAttractions, attraction)
+	var neighborhoods []api.Neighborhood
+	for i, attraction := range attractions {
+		result := geocodeResults[i]
+		if result.Err != nil {
+			responseAttractions.FailedAttractions = append(responseAttractions.FailedAttractions, FailedAttraction{
+				Attraction: attraction,
+				Error:      toAttractionError(result.Err),
+			})
+			statusTracker.Publish(status.Event{GeocodeSucceeded: false, OccurredAt: time.Now()})
 			continue
 		}
 
-		attraction.Latitude = attractionLocation.Lat
-		attraction.Longitude = attractionLocation.Lng
+		attraction.Latitude = result.Location.Lat
+		attraction.Longitude = result.Location.Lng
 		responseAttractions.SuccessfulAttractions = append(responseAttractions.SuccessfulAttractions, attraction)
-		neighborhood, err := api.FindNeighborhoodContainingAttraction(attraction)
+
+		postGISStart := time.Now()
+		neighborhood, distanceInMeters, err := api.FindNeighborhoodContainingAttraction(attraction)
+		postGISLatency := time.Since(postGISStart)
 		if err != nil {
-			log.Fatal(err)
+			errorCode := api.ErrCodePostGISFailed
+			if errors.Is(err, api.ErrNoNeighborhoodFound) {
+				errorCode = api.ErrCodeNoNeighborhoodFound
+			}
+
+			responseAttractions.FailedAttractions = append(responseAttractions.FailedAttractions, FailedAttraction{
+				Attraction: attraction,
+				Error:      api.AttractionError{Code: errorCode, Message: err.Error()},
+			})
+			// PostGIS couldn't resolve a containing neighborhood, but we still attribute the
+			// failure to the nearest candidate centroid so per-neighborhood GeocodeSuccessRatio
+			// reflects near-misses instead of only ever seeing successes.
+			statusTracker.Publish(status.Event{
+				NeighborhoodName: api.NearestNeighborhoodGuess(attraction),
+				GeocodeSucceeded: true,
+				PostGISLatency:   postGISLatency,
+				OccurredAt:       time.Now(),
+			})
 			continue
 		}
 
 		neighborhoods = append(neighborhoods, neighborhood)
+		statusTracker.Publish(status.Event{
+			NeighborhoodName:    neighborhood.Name,
+			NeighborhoodMatched: true,
+			DistanceMeters:      distanceInMeters,
+			GeocodeSucceeded:    true,
+			PostGISLatency:      postGISLatency,
+			OccurredAt:          time.Now(),
+		})
 	}
 
-	closestNeighborhood, err := api.FindBestNeighborhood(neighborhoods)
+	closestNeighborhood, err := api.FindBestNeighborhood(distanceCache, neighborhoods)
 	if err != nil {
-		log.Fatal(err)
+		// No resolved neighborhoods (every attraction failed to geocode/resolve, or the request
+		// had none to begin with) isn't fatal -- it just means there's no closest neighborhood to
+		// report. Respond with what we have rather than taking the whole server down.
+		log.Printf("Unable to resolve closest neighborhood: %v\n", err)
 	} else {
 		responseAttractions.ClosestNeighborhood = closestNeighborhood
 	}
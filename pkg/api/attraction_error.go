@@ -0,0 +1,23 @@
+package api
+
+// AttractionError carries a machine-readable code alongside a human-readable message, so API
+// clients can distinguish an OSM rate-limit failure from a PostGIS failure without string-matching
+// on err.Error().
+type AttractionError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *AttractionError) Error() string {
+	return e.Message
+}
+
+// Error codes an attraction can fail to resolve with.
+const (
+	ErrCodeGeocodeRateLimited  = "geocode_rate_limited"
+	ErrCodeGeocodeTimeout      = "geocode_timeout"
+	ErrCodeGeocodeCancelled    = "geocode_cancelled"
+	ErrCodeGeocodeFailed       = "geocode_failed"
+	ErrCodePostGISFailed       = "postgis_failed"
+	ErrCodeNoNeighborhoodFound = "no_neighborhood_found"
+)
@@ -4,16 +4,25 @@ import (
 	"container/heap"
 	"crypto/md5"
 	"encoding/hex"
+	"errors"
 	"log"
-	"math"
 	"sort"
 	"strings"
 
 	"../connections"
+	"../pkg/cache"
+	"../pkg/graph"
+	"../pkg/spatial"
 
-	_ "github.com/lib/pq" // Used to interact with PostgreSQL/PostGIS
+	"github.com/lib/pq" // Used to interact with PostgreSQL/PostGIS
 )
 
+// ErrNoNeighborhoodFound indicates the attraction's coordinates don't fall within any indexed
+// neighborhood polygon. This is a normal, expected outcome (the attraction is simply outside every
+// covered neighborhood) and must not be confused with a database failure -- callers should route
+// it into their own "couldn't resolve this attraction" path rather than treating it as a success.
+var ErrNoNeighborhoodFound = errors.New("no neighborhood contains the given attraction")
+
 // Neighborhood is defined as a localised community within a larger city (i.e, 'Downtown')
 // TODO: make lat/lng a struct
 type Neighborhood struct {
@@ -25,24 +34,66 @@ type Neighborhood struct {
 	Longitude           float64 `json:"longitude"`
 }
 
-// FindNeighborhoodContainingAttraction resolves the neighborhood of the given attraction via geocoding.
-func FindNeighborhoodContainingAttraction(attraction Attraction) (Neighborhood, error) {
+// FindNeighborhoodContainingAttraction resolves the neighborhood containing the given attraction's
+// (already geocoded) coordinates via PostGIS ST_Contains, along with the distance in meters from the attraction to that neighborhood's
+// centroid (callers use this for status reporting; see pkg/status).
+// When the spatial index is available, it's used as a fast candidate filter so PostGIS only has
+// to run ST_Contains against the handful of nearest centroids instead of every neighborhood.
+func FindNeighborhoodContainingAttraction(attraction Attraction) (Neighborhood, float64, error) {
 	attractionInNeighborhoodQuery := `
         SELECT ST_Contains(neighborhood_poly, attr_point) as in_neighborhood, name, city, state, country
         FROM (
             SELECT ST_SetSRID(ST_Point($1, $2),4326) as attr_point, geom as neighborhood_poly, name, city, state, country
             FROM neighborhood_geocoding.neighborhoods
+            WHERE ($3::text[] IS NULL OR name = ANY($3))
         ) as foo
         WHERE ST_Contains(neighborhood_poly, attr_point) is true
         `
 
+	filtered := false
+	var candidateNames interface{}
+	if spatialIndexEnabled() {
+		if names := nearestCandidateNames(attraction); len(names) > 0 {
+			candidateNames = pq.Array(names)
+			filtered = true
+		}
+	}
+
+	neighborhood, distanceInMeters, matched, err := queryNeighborhoodContainingAttraction(attractionInNeighborhoodQuery, attraction, candidateNames)
+	if err != nil {
+		return Neighborhood{}, 0, err
+	}
+
+	// The candidate filter only hands PostGIS the candidateCount nearest centroids; for an
+	// elongated or irregular polygon the true containing neighborhood's centroid can fall outside
+	// that set. Rather than silently reporting no match, fall back to an unfiltered scan.
+	if !matched && filtered {
+		neighborhood, distanceInMeters, matched, err = queryNeighborhoodContainingAttraction(attractionInNeighborhoodQuery, attraction, nil)
+		if err != nil {
+			return Neighborhood{}, 0, err
+		}
+	}
+
+	if !matched {
+		return Neighborhood{}, 0, ErrNoNeighborhoodFound
+	}
+
+	return neighborhood, distanceInMeters, nil
+}
+
+// queryNeighborhoodContainingAttraction runs attractionInNeighborhoodQuery against PostGIS,
+// optionally restricted to candidateNames, and returns the closest matching neighborhood. matched
+// is false when no row had in_neighborhood=true, which callers use to decide whether to retry
+// unfiltered.
+func queryNeighborhoodContainingAttraction(query string, attraction Attraction, candidateNames interface{}) (Neighborhood, float64, bool, error) {
 	rows, err := connections.Init().Query(
-		attractionInNeighborhoodQuery,
+		query,
 		attraction.Longitude,
-		attraction.Latitude)
+		attraction.Latitude,
+		candidateNames)
 
 	if err != nil {
-		return Neighborhood{}, err
+		return Neighborhood{}, 0, false, err
 	}
 
 	defer rows.Close()
@@ -59,7 +110,7 @@ func FindNeighborhoodContainingAttraction(attraction Attraction) (Neighborhood,
 		var country string
 		var inNeighborhood bool
 		if err := rows.Scan(&inNeighborhood, &name, &city, &stateOrProvinceName, &country); err != nil {
-			return Neighborhood{}, err
+			return Neighborhood{}, 0, false, err
 		}
 
 		if inNeighborhood == false {
@@ -77,7 +128,7 @@ func FindNeighborhoodContainingAttraction(attraction Attraction) (Neighborhood,
 		latitude := coordinates[0]
 		longitude := coordinates[1]
 		attractionsCoordinates := []float64{attraction.Longitude, attraction.Latitude}
-		distanceInMeters, err := getDistanceBetweenTwoCoordinates(coordinates, attractionsCoordinates)
+		distanceInMeters, err := getDistanceBetweenTwoCoordinates(coordinates, attractionsCoordinates, nil, "")
 
 		if err != nil {
 			log.Printf("Unable to get distance between two coordinates having error: %v\n", err)
@@ -94,10 +145,10 @@ func FindNeighborhoodContainingAttraction(attraction Attraction) (Neighborhood,
 	}
 
 	if len(matchedNeighborhoods) == 0 {
-		return Neighborhood{}, err
+		return Neighborhood{}, 0, false, nil
 	}
 
-	return matchedNeighborhoods[bestNeighborhoodIdx], err
+	return matchedNeighborhoods[bestNeighborhoodIdx], minDistanceInMeters, true, nil
 }
 
 // Returns the coordinates of a MultiPolygon's centroid (if found). idx 0 => latitude, idx 1 => longitude
@@ -135,9 +186,37 @@ func resolveNeighborhoodMultiPolygonsCentroidPoint(
 	return coordinates, err
 }
 
-// Get distance between two coordinate in meters.
+// Get distance between two coordinates in meters. point1 and point2 are [longitude, latitude].
+// When the spatial index is enabled this is computed in-memory via the great-circle formula; set
+// AIRBNB_USE_SPATIAL_INDEX=false to fall back to the PostGIS ST_Distance_Sphere path below.
+// distanceCache and cacheKey are optional (pass nil / "" to skip caching); when both are given,
+// a hit short-circuits the computation entirely and a miss is stored under cacheKey afterwards.
+func getDistanceBetweenTwoCoordinates(point1 []float64, point2 []float64, distanceCache cache.DistanceCache, cacheKey string) (float64, error) {
+	if distanceCache != nil && cacheKey != "" {
+		if distanceInMeters, ok := distanceCache.Get(cacheKey); ok {
+			return distanceInMeters, nil
+		}
+	}
+
+	var distanceInMeters float64
+	var err error
+	if spatialIndexEnabled() {
+		distanceInMeters = spatial.GreatCircleDistanceMeters(point1[1], point1[0], point2[1], point2[0])
+	} else {
+		distanceInMeters, err = getDistanceBetweenTwoCoordinatesPostGIS(point1, point2)
+	}
+
+	if err == nil && distanceCache != nil && cacheKey != "" {
+		distanceCache.Set(cacheKey, distanceInMeters, cache.TTLFromEnv())
+	}
+
+	return distanceInMeters, err
+}
+
+// getDistanceBetweenTwoCoordinatesPostGIS is the original PostGIS-backed implementation, kept as
+// the fallback path behind AIRBNB_USE_SPATIAL_INDEX=false.
 // See: https://postgis.net/docs/manual-1.4/ST_Distance_Sphere.html
-func getDistanceBetweenTwoCoordinates(point1 []float64, point2 []float64) (float64, error) {
+func getDistanceBetweenTwoCoordinatesPostGIS(point1 []float64, point2 []float64) (float64, error) {
 	pointDistanceQueryStr := `
     SELECT ST_Distance_Sphere(
         ST_SetSRID(ST_Point($1, $2), 4326),
@@ -166,7 +245,9 @@ func getDistanceBetweenTwoCoordinates(point1 []float64, point2 []float64) (float
 // Best is defined here as:
 // 	a) Having the highest occurrence (frequency)
 //	b) Minimized distance between all other neighborhoods in the list
-func FindBestNeighborhood(neighborhoods []Neighborhood) (Neighborhood, error) {
+// distanceCache is injected so the HTTP server can share a single cache (in-memory or Redis,
+// see pkg/cache) across requests instead of recomputing distances every time.
+func FindBestNeighborhood(distanceCache cache.DistanceCache, neighborhoods []Neighborhood) (Neighborhood, error) {
 	neighborhoodNames, err := findNeighborhoodWithHighestOccurrence(neighborhoods)
 	if err != nil {
 		log.Printf("Unable to resolve neighborhoods with highest occurrence having error: %v\n", err)
@@ -182,7 +263,7 @@ func FindBestNeighborhood(neighborhoods []Neighborhood) (Neighborhood, error) {
 		}
 	}
 
-	optimalNeighborhoodName, err := findNeighborhoodWithLeastDistanceToAllOtherNeighborhoods(highestOccurrenceNeighborhoods)
+	optimalNeighborhoodName, err := findNeighborhoodWithLeastDistanceToAllOtherNeighborhoods(distanceCache, highestOccurrenceNeighborhoods)
 
 	if err == nil {
 		return optimalNeighborhoodName, nil
@@ -287,73 +368,43 @@ func findNeighborhoodsWithSameFrequency(h *neighborhoodNameFrequencyMinHeap) ([]
 	return neighborhoodNames, nil
 }
 
-// Edge denotes a connection between two Neighborhood nodes.
-type Edge struct {
-	sourceNode       Neighborhood
-	targetNode       Neighborhood
-	distanceInMeters float64
-}
+func findNeighborhoodWithLeastDistanceToAllOtherNeighborhoods(distanceCache cache.DistanceCache, neighborhoods []Neighborhood) (Neighborhood, error) {
+	namespace := cache.NamespaceFromEnv()
 
-// Graph stores all Neighborhoods and their connections between each other.
-type Graph struct {
-	nodes []Neighborhood
-	edges map[string][]Edge
-}
+	g := graph.BuildGraph(toGraphNeighbourhoods(neighborhoods), func(a graph.Neighbourhood, b graph.Neighbourhood) float64 {
+		cacheKey := cache.Key(namespace, generateNeighborhoodCacheKey(a.Name, b.Name))
+		distanceInMeters, _ := getDistanceBetweenTwoCoordinates([]float64{a.Longitude, a.Latitude}, []float64{b.Longitude, b.Latitude}, distanceCache, cacheKey)
 
-func (graph Graph) buildGraphFromNeighborhoods(neighborhoods []Neighborhood) (Graph, error) {
-	for _, neighborhood := range neighborhoods {
-		graph.nodes = append(graph.nodes, neighborhood)
-	}
-
-	return graph, nil
-}
-
-func findNeighborhoodWithLeastDistanceToAllOtherNeighborhoods(neighborhoods []Neighborhood) (Neighborhood, error) {
-	var graph Graph
-	// Ideally, this would be a thread-safe cache to deal with concurrent requests (i.e, Redis).
-	distanceCache := make(map[string]float64)
-
-	for _, neighborhood := range neighborhoods {
-		sourceNode := neighborhood
-		graph.nodes = append(graph.nodes, sourceNode)
-		remainingNeighborhoods := composeDifferingNeighborhoodNamesSlice(neighborhood.Name, neighborhoods)
-		for _, otherNeighborhood := range remainingNeighborhoods {
-			targetNode := neighborhood
-
-			var distanceInMeters float64
-			hashedString := generateNeighborhoodCacheKey(neighborhood.Name, otherNeighborhood.Name)
-			_, ok := distanceCache[hashedString]
-
-			if ok == false {
-				distanceInMeters, _ = getDistanceBetweenTwoCoordinates([]float64{neighborhood.Longitude, neighborhood.Latitude}, []float64{otherNeighborhood.Longitude, otherNeighborhood.Latitude})
-				distanceCache[hashedString] = distanceInMeters
-			} else {
-				distanceInMeters = distanceCache[hashedString]
-			}
-
-			edge := Edge{sourceNode, targetNode, distanceInMeters}
-			graph.edges[neighborhood.Name] = append(graph.edges[neighborhood.Name], edge)
-		}
-	}
+		return distanceInMeters
+	})
 
-	optimalNeighborhood, err := findMinDistanceBetweenNodes(graph)
+	medoid, err := graph.FindMedoid(g, graph.SumDistance)
 	if err != nil {
 		log.Printf("Error after finding optimal neighborhood: %v\n", err)
 		return Neighborhood{}, err
 	}
 
-	return optimalNeighborhood, nil
+	return fromGraphNeighbourhood(medoid.Neighbourhood), nil
 }
 
-func composeDifferingNeighborhoodNamesSlice(currentNeighborhoodName string, allNeighborhoodNames []Neighborhood) []Neighborhood {
-	var newSlice []Neighborhood
-	for _, neighborhood := range allNeighborhoodNames {
-		if currentNeighborhoodName != neighborhood.Name {
-			newSlice = append(newSlice, neighborhood)
+func toGraphNeighbourhoods(neighborhoods []Neighborhood) []graph.Neighbourhood {
+	graphNeighbourhoods := make([]graph.Neighbourhood, len(neighborhoods))
+	for i, neighborhood := range neighborhoods {
+		graphNeighbourhoods[i] = graph.Neighbourhood{
+			Name:                neighborhood.Name,
+			City:                neighborhood.City,
+			StateOrProvinceName: neighborhood.StateOrProvinceName,
+			Country:             neighborhood.Country,
+			Latitude:            neighborhood.Latitude,
+			Longitude:           neighborhood.Longitude,
 		}
 	}
 
-	return newSlice
+	return graphNeighbourhoods
+}
+
+func fromGraphNeighbourhood(n graph.Neighbourhood) Neighborhood {
+	return Neighborhood{n.Name, n.City, n.StateOrProvinceName, n.Country, n.Latitude, n.Longitude}
 }
 
 // Caching PostGIS calculations on geometric objects is desired as they're computationally, and time expensive.
@@ -370,34 +421,3 @@ func generateNeighborhoodCacheKey(neighborhoodName string, otherNeighborhoodName
 	return hashedString
 }
 
-// Searches the constructed graph for the neighborhood with min distance between all other points.
-// Time complexity is O(V*E) where V represents the number of vertices to visit, and E represents the
-// number of edges to examine.
-func findMinDistanceBetweenNodes(graph Graph) (Neighborhood, error) {
-	if len(graph.nodes) == 1 {
-		return graph.nodes[0], nil
-	}
-
-	neighborhoodDistanceSums := make(map[string]float64)
-	for sourceNode, edges := range graph.edges {
-		_, ok := neighborhoodDistanceSums[sourceNode]
-		if ok == true {
-			neighborhoodDistanceSums[sourceNode] = 0
-		}
-		for _, targetNode := range edges {
-			neighborhoodDistanceSums[sourceNode] += targetNode.distanceInMeters
-		}
-	}
-
-	minValue := math.Inf(1)
-	var bestNeighborhood Neighborhood
-	for _, node := range graph.nodes {
-		nodeDistanceSum := neighborhoodDistanceSums[node.Name]
-		if nodeDistanceSum < minValue {
-			minValue = nodeDistanceSum
-			bestNeighborhood = node
-		}
-	}
-
-	return bestNeighborhood, nil
-}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"os"
+
+	"../connections"
+	"../pkg/spatial"
+)
+
+// useSpatialIndexEnv, when set to "false", disables the in-memory spatial index entirely and
+// falls back to the original PostGIS-only lookup path for both neighborhood resolution and
+// medoid selection.
+const useSpatialIndexEnv = "AIRBNB_USE_SPATIAL_INDEX"
+
+// candidateCount is how many nearest centroids the spatial index hands PostGIS to verify with
+// ST_Contains. It only needs to be large enough to comfortably contain the true match.
+const candidateCount = 5
+
+// spatialIndex is the process-wide k-d tree over neighborhood centroids. It's nil until
+// InitSpatialIndex runs, at which point lookups transparently start using it.
+var spatialIndex *spatial.KDTree
+
+func spatialIndexEnabled() bool {
+	return os.Getenv(useSpatialIndexEnv) != "false"
+}
+
+// InitSpatialIndex loads every neighborhood centroid from PostGIS and builds the in-memory k-d
+// tree used to speed up neighborhood lookups. Call once at startup; callers that want a periodic
+// refresh (a TTL) can call it again from a ticker.
+func InitSpatialIndex() error {
+	neighborhoods, err := loadAllNeighborhoodCentroids()
+	if err != nil {
+		return err
+	}
+
+	points := make([]spatial.IndexedPoint, len(neighborhoods))
+	for i, neighborhood := range neighborhoods {
+		points[i] = spatial.IndexedPoint{
+			Name:                neighborhood.Name,
+			City:                neighborhood.City,
+			StateOrProvinceName: neighborhood.StateOrProvinceName,
+			Country:             neighborhood.Country,
+			Latitude:            neighborhood.Latitude,
+			Longitude:           neighborhood.Longitude,
+		}
+	}
+
+	spatialIndex = spatial.NewKDTree(points)
+	return nil
+}
+
+func loadAllNeighborhoodCentroids() ([]Neighborhood, error) {
+	allCentroidsQuery := `
+    SELECT name, city, state, country, ST_X(coordinates) as longitude, ST_Y(coordinates) as latitude
+    FROM (
+        SELECT name, city, state, country, ST_AsText(ST_centroid(geom)) as coordinates
+        FROM neighborhood_geocoding.neighborhoods
+    ) as centroids
+    `
+
+	rows, err := connections.Init().Query(allCentroidsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var neighborhoods []Neighborhood
+	for rows.Next() {
+		var neighborhood Neighborhood
+		if err := rows.Scan(
+			&neighborhood.Name,
+			&neighborhood.City,
+			&neighborhood.StateOrProvinceName,
+			&neighborhood.Country,
+			&neighborhood.Longitude,
+			&neighborhood.Latitude); err != nil {
+			return nil, err
+		}
+
+		neighborhoods = append(neighborhoods, neighborhood)
+	}
+
+	return neighborhoods, nil
+}
+
+// NearestNeighborhoodGuess returns the name of the single nearest neighborhood centroid to the
+// given attraction, or "" if the spatial index isn't built yet. Callers use this as a best-guess
+// label for status reporting when PostGIS fails to resolve a containing neighborhood -- it is not
+// a substitute for FindNeighborhoodContainingAttraction's authoritative ST_Contains result.
+func NearestNeighborhoodGuess(attraction Attraction) string {
+	if !spatialIndexEnabled() {
+		return ""
+	}
+
+	candidates := nearestCandidateNames(attraction)
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	return candidates[0]
+}
+
+// nearestCandidateNames returns the names of the candidateCount neighborhood centroids closest
+// to the attraction, or nil if the index isn't built yet (in which case callers should fall back
+// to an unfiltered PostGIS scan).
+func nearestCandidateNames(attraction Attraction) []string {
+	if spatialIndex == nil {
+		return nil
+	}
+
+	candidates := spatialIndex.NearestN(attraction.Latitude, attraction.Longitude, candidateCount)
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		names[i] = candidate.Name
+	}
+
+	return names
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"math/rand"
+	"testing"
+
+	"../pkg/spatial"
+)
+
+// testPolygon is a simple closed ring of lat/lng vertices, used here as a stand-in for the
+// ST_Contains polygons PostGIS holds per neighborhood.
+type testPolygon struct {
+	name     string
+	vertices [][2]float64 // [lat, lng]
+}
+
+func (p testPolygon) centroid() (float64, float64) {
+	var latSum, lngSum float64
+	for _, v := range p.vertices {
+		latSum += v[0]
+		lngSum += v[1]
+	}
+	n := float64(len(p.vertices))
+	return latSum / n, lngSum / n
+}
+
+// contains is a standard even-odd ray-casting point-in-polygon test, used in place of
+// ST_Contains so this test can run entirely in memory.
+func (p testPolygon) contains(lat float64, lng float64) bool {
+	inside := false
+	n := len(p.vertices)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		yi, xi := p.vertices[i][0], p.vertices[i][1]
+		yj, xj := p.vertices[j][0], p.vertices[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func square(name string, centerLat, centerLng, halfSide float64) testPolygon {
+	return testPolygon{name: name, vertices: [][2]float64{
+		{centerLat - halfSide, centerLng - halfSide},
+		{centerLat - halfSide, centerLng + halfSide},
+		{centerLat + halfSide, centerLng + halfSide},
+		{centerLat + halfSide, centerLng - halfSide},
+	}}
+}
+
+// elongatedPolygon is a long, thin strip whose centroid is far from most of the area it covers -
+// the shape the review comment called out as able to defeat the nearest-centroid candidate
+// filter.
+func elongatedPolygon(name string, centerLat, centerLng, halfLength, halfWidth float64) testPolygon {
+	return testPolygon{name: name, vertices: [][2]float64{
+		{centerLat - halfWidth, centerLng - halfLength},
+		{centerLat - halfWidth, centerLng + halfLength},
+		{centerLat + halfWidth, centerLng + halfLength},
+		{centerLat + halfWidth, centerLng - halfLength},
+	}}
+}
+
+func realisticPolygonSet() []testPolygon {
+	return []testPolygon{
+		square("Downtown", 40.7128, -74.0060, 0.02),
+		square("Southside", 40.6800, -74.0100, 0.02),
+		square("Westside", 40.7200, -74.0500, 0.02),
+		square("East Bay", 40.7400, -73.9700, 0.02),
+		square("Uptown", 40.7700, -73.9800, 0.02),
+		square("Harborview", 40.6600, -73.9900, 0.02),
+		elongatedPolygon("Riverside Strip", 40.7100, -74.1500, 0.30, 0.01),
+	}
+}
+
+// buildTestIndex returns a k-d tree over the given polygons' centroids, mirroring how
+// InitSpatialIndex builds spatialIndex from PostGIS centroids at startup.
+func buildTestIndex(polygons []testPolygon) *spatial.KDTree {
+	points := make([]spatial.IndexedPoint, len(polygons))
+	for i, p := range polygons {
+		lat, lng := p.centroid()
+		points[i] = spatial.IndexedPoint{Name: p.name, Latitude: lat, Longitude: lng}
+	}
+	return spatial.NewKDTree(points)
+}
+
+// TestNearestCandidateNamesAgreesWithFullContainsScan confirms that, for a realistic set of
+// roughly-compact neighborhood polygons, the candidate-filtered centroid search
+// (nearestCandidateNames) always includes whichever neighborhood a full ST_Contains-style scan
+// would find containing the attraction. It also demonstrates the known gap: an elongated polygon
+// whose centroid isn't among the candidateCount nearest centroids is missed by the filter, which
+// is exactly why FindNeighborhoodContainingAttraction falls back to an unfiltered query when the
+// filtered query comes back empty.
+func TestNearestCandidateNamesAgreesWithFullContainsScan(t *testing.T) {
+	polygons := realisticPolygonSet()
+	spatialIndex = buildTestIndex(polygons)
+	defer func() { spatialIndex = nil }()
+
+	rng := rand.New(rand.NewSource(7))
+
+	var missedByFilterButContained int
+	for trial := 0; trial < 200; trial++ {
+		lat := 40.60 + rng.Float64()*0.25
+		lng := -74.20 + rng.Float64()*0.30
+
+		var containingPolygon string
+		for _, p := range polygons {
+			if p.contains(lat, lng) {
+				containingPolygon = p.name
+				break
+			}
+		}
+		if containingPolygon == "" {
+			continue
+		}
+
+		candidates := nearestCandidateNames(Attraction{Latitude: lat, Longitude: lng})
+		found := false
+		for _, name := range candidates {
+			if name == containingPolygon {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missedByFilterButContained++
+			if containingPolygon != "Riverside Strip" {
+				t.Errorf("trial %d: attraction (%.4f, %.4f) is contained by %q but the candidate filter missed it; candidates=%v",
+					trial, lat, lng, containingPolygon, candidates)
+			}
+		}
+	}
+
+	// The elongated "Riverside Strip" polygon is expected to occasionally fall outside the
+	// candidate filter's reach - that's the scenario FindNeighborhoodContainingAttraction's
+	// unfiltered fallback exists to handle, not a regression in this test.
+	if missedByFilterButContained == 0 {
+		t.Log("no candidate-filter misses observed in this run; consider widening the polygon set if this stays true")
+	}
+}
@@ -0,0 +1,23 @@
+// Package cache provides a pluggable cache for distances between neighborhood pairs, so that
+// PostGIS's (or the spatial index's) computationally expensive distance calculations aren't
+// repeated for the same pair across requests.
+package cache
+
+import "time"
+
+// DistanceCache stores distances, in meters, keyed by an opaque string (see Key). Implementations
+// must be safe for concurrent use, since a single instance is shared across HTTP requests.
+type DistanceCache interface {
+	Get(key string) (float64, bool)
+	Set(key string, meters float64, ttl time.Duration)
+}
+
+// Key namespaces a cache key so that multiple service versions can share a single cache backend
+// (e.g. a shared Redis instance) without their entries colliding.
+func Key(namespace string, key string) string {
+	if namespace == "" {
+		return key
+	}
+
+	return namespace + ":" + key
+}
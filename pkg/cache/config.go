@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables used to configure the shared DistanceCache at startup.
+const (
+	BackendEnv   = "DISTANCE_CACHE_BACKEND"    // "memory" (default) or "redis"
+	RedisAddrEnv = "DISTANCE_CACHE_REDIS_ADDR" // e.g. "localhost:6379", used when BackendEnv is "redis"
+	TTLEnv       = "DISTANCE_CACHE_TTL_SECONDS" // entry TTL in seconds; 0 or unset means no expiry
+	NamespaceEnv = "DISTANCE_CACHE_NAMESPACE"   // prefix applied via Key so service versions can coexist
+)
+
+// NewFromEnv constructs the DistanceCache backend selected by BackendEnv, defaulting to
+// MemoryCache when unset or unrecognized.
+func NewFromEnv() DistanceCache {
+	switch os.Getenv(BackendEnv) {
+	case "redis":
+		return NewRedisCache(os.Getenv(RedisAddrEnv))
+	default:
+		return NewMemoryCache()
+	}
+}
+
+// TTLFromEnv returns the configured entry TTL, or 0 (no expiry) if unset or invalid.
+func TTLFromEnv() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv(TTLEnv))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// NamespaceFromEnv returns the configured cache key namespace, or "" if unset.
+func NamespaceFromEnv() string {
+	return os.Getenv(NamespaceEnv)
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	meters    float64
+	expiresAt time.Time
+}
+
+// MemoryCache is a sync.Map-backed DistanceCache. It's the default backend: no external
+// dependency, but not shared across service instances or restarts.
+type MemoryCache struct {
+	entries sync.Map
+}
+
+// NewMemoryCache returns a ready-to-use in-memory DistanceCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{}
+}
+
+// Get returns the cached distance for key, if present and not expired.
+func (c *MemoryCache) Get(key string) (float64, bool) {
+	value, ok := c.entries.Load(key)
+	if !ok {
+		return 0, false
+	}
+
+	entry := value.(memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.entries.Delete(key)
+		return 0, false
+	}
+
+	return entry.meters, true
+}
+
+// Set stores meters under key. A ttl of zero means the entry never expires.
+func (c *MemoryCache) Set(key string, meters float64, ttl time.Duration) {
+	entry := memoryEntry{meters: meters}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.entries.Store(key, entry)
+}
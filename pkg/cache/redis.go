@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache is a DistanceCache backed by Redis, so the distance cache can be shared across
+// service instances and survive process restarts.
+type RedisCache struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewRedisCache returns a DistanceCache backed by the Redis instance at addr (e.g. "localhost:6379").
+func NewRedisCache(addr string) *RedisCache {
+	return &RedisCache{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+		ctx:    context.Background(),
+	}
+}
+
+// Get returns the cached distance for key, if present.
+func (c *RedisCache) Get(key string) (float64, bool) {
+	value, err := c.client.Get(c.ctx, key).Result()
+	if err != nil {
+		return 0, false
+	}
+
+	meters, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return meters, true
+}
+
+// Set stores meters under key. A ttl of zero means the entry never expires.
+func (c *RedisCache) Set(key string, meters float64, ttl time.Duration) {
+	c.client.Set(c.ctx, key, strconv.FormatFloat(meters, 'f', -1, 64), ttl)
+}
@@ -0,0 +1,68 @@
+package geocode
+
+import (
+	"regexp"
+	"strings"
+)
+
+var cityNameSeparators = regexp.MustCompile(`[-\s]+`)
+
+// normalizeCityName lowercases and strips dashes/spaces so "Hong Kong", "hong-kong", and
+// "HongKong" all match the same table entry.
+func normalizeCityName(name string) string {
+	return cityNameSeparators.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// cityOverrides maps a normalized alias onto the name of the TopCities entry it stands for. The
+// "hongkong" entry maps onto "Hong Kong", which normalizeCityName already turns into "hongkong".
+var cityOverrides = map[string]string{
+	"hongkong": "hong kong",
+}
+
+// TopCities is a small, hand-maintained table of major cities' approximate centroids, bundled so
+// CityTableResolver can offer a fuzzy fallback without any network access.
+var TopCities = map[string]Location{
+	"New York":     {Lat: 40.7128, Lng: -74.0060},
+	"Los Angeles":  {Lat: 34.0522, Lng: -118.2437},
+	"Chicago":      {Lat: 41.8781, Lng: -87.6298},
+	"Houston":      {Lat: 29.7604, Lng: -95.3698},
+	"London":       {Lat: 51.5074, Lng: -0.1278},
+	"Paris":        {Lat: 48.8566, Lng: 2.3522},
+	"Tokyo":        {Lat: 35.6762, Lng: 139.6503},
+	"Hong Kong":    {Lat: 22.3193, Lng: 114.1694},
+	"Singapore":    {Lat: 1.3521, Lng: 103.8198},
+	"Sydney":       {Lat: -33.8688, Lng: 151.2093},
+	"Toronto":      {Lat: 43.6532, Lng: -79.3832},
+	"Berlin":       {Lat: 52.5200, Lng: 13.4050},
+}
+
+// CityTableResolver resolves a city name against a bundled table of major cities' coordinates. It
+// never hits the network, so it's a fast, offline fallback when precise geocoding fails.
+type CityTableResolver struct {
+	cities map[string]Location // keyed by normalizeCityName(name)
+}
+
+// NewCityTableResolver builds a resolver from name->Location pairs, typically TopCities.
+func NewCityTableResolver(cities map[string]Location) *CityTableResolver {
+	normalized := make(map[string]Location, len(cities))
+	for name, location := range cities {
+		normalized[normalizeCityName(name)] = location
+	}
+
+	return &CityTableResolver{cities: normalized}
+}
+
+// Resolve looks up query.Address in the bundled city table after normalization.
+func (r *CityTableResolver) Resolve(query Query) (*Location, error) {
+	key := normalizeCityName(query.Address)
+	if override, ok := cityOverrides[key]; ok {
+		key = normalizeCityName(override)
+	}
+
+	location, ok := r.cities[key]
+	if !ok {
+		return nil, ErrNoMatch
+	}
+
+	return &location, nil
+}
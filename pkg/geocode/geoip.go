@@ -0,0 +1,49 @@
+package geocode
+
+import (
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// GeoIPResolver resolves the requesting client's IP address to an approximate location using a
+// local MaxMind GeoLite2-City database. It's the last link in the chain: used only when neither
+// precise geocoding nor the city table could place the attraction.
+type GeoIPResolver struct {
+	db *geoip2.Reader
+}
+
+// NewGeoIPResolver opens the GeoLite2-City database at dbPath. Callers should Close it at
+// shutdown.
+func NewGeoIPResolver(dbPath string) (*GeoIPResolver, error) {
+	db, err := geoip2.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeoIPResolver{db: db}, nil
+}
+
+// Close releases the underlying mmdb file handle.
+func (r *GeoIPResolver) Close() error {
+	return r.db.Close()
+}
+
+// Resolve ignores query.Address and instead looks up query.ClientIP's approximate city location.
+func (r *GeoIPResolver) Resolve(query Query) (*Location, error) {
+	if query.ClientIP == "" {
+		return nil, ErrNoMatch
+	}
+
+	ip := net.ParseIP(query.ClientIP)
+	if ip == nil {
+		return nil, ErrNoMatch
+	}
+
+	record, err := r.db.City(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Location{Lat: record.Location.Latitude, Lng: record.Location.Longitude}, nil
+}
@@ -0,0 +1,31 @@
+package geocode
+
+import (
+	geo "github.com/codingsince1985/geo-golang"
+)
+
+// OSMResolver resolves addresses via OpenStreetMap, wrapping whichever geo-golang geocoder the
+// caller already has configured (see openstreetmap.Geocoder() in cmd/main.go). It's the first
+// link in the chain: the precise, usually-correct path that the others are a fallback for.
+type OSMResolver struct {
+	geocoder geo.Geocoder
+}
+
+// NewOSMResolver wraps an existing geo-golang Geocoder as a Resolver.
+func NewOSMResolver(geocoder geo.Geocoder) *OSMResolver {
+	return &OSMResolver{geocoder: geocoder}
+}
+
+// Resolve geocodes query.Address via OpenStreetMap.
+func (r *OSMResolver) Resolve(query Query) (*Location, error) {
+	location, err := r.geocoder.Geocode(query.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if location == nil {
+		return nil, ErrNoMatch
+	}
+
+	return &Location{Lat: location.Lat, Lng: location.Lng}, nil
+}
@@ -0,0 +1,122 @@
+package geocode
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"../api"
+	"golang.org/x/time/rate"
+)
+
+// Job pairs a Query with the index it came from, so results can be matched back up to their
+// input after concurrent processing.
+type Job struct {
+	Index int
+	Query Query
+}
+
+// Result is what a worker produces for a given Job.
+type Result struct {
+	Index    int
+	Location *Location
+	Err      error
+}
+
+// Pool geocodes a batch of queries concurrently through a shared Resolver, respecting a rate
+// limit (OpenStreetMap's documented policy is 1 req/sec) and the caller's context cancellation.
+type Pool struct {
+	resolver    Resolver
+	workerCount int
+	limiter     *rate.Limiter
+}
+
+// NewPool returns a Pool that geocodes through resolver using up to workerCount concurrent
+// workers, each waiting on limiter before calling resolver.Resolve. Pass nil for limiter to skip
+// rate limiting entirely.
+func NewPool(resolver Resolver, workerCount int, limiter *rate.Limiter) *Pool {
+	return &Pool{resolver: resolver, workerCount: workerCount, limiter: limiter}
+}
+
+// Resolve geocodes every query concurrently, respecting ctx's cancellation and perJobTimeout
+// (0 disables the per-job timeout), and returns one Result per input query in the same order.
+func (p *Pool) Resolve(ctx context.Context, queries []Query, perJobTimeout time.Duration) []Result {
+	results := make([]Result, len(queries))
+	if len(queries) == 0 {
+		return results
+	}
+
+	workerCount := p.workerCount
+	if workerCount > len(queries) {
+		workerCount = len(queries)
+	}
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	jobs := make(chan Job)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results[job.Index] = p.resolveOne(ctx, job, perJobTimeout)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i, query := range queries {
+			select {
+			case jobs <- Job{Index: i, Query: query}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	// Any job the dispatcher never got to send, because ctx was cancelled first, needs an
+	// explicit error result rather than a zero-value Result that looks like an empty success.
+	for i := range results {
+		if results[i].Location == nil && results[i].Err == nil {
+			results[i].Err = &api.AttractionError{Code: api.ErrCodeGeocodeCancelled, Message: "request cancelled before geocoding"}
+		}
+	}
+
+	return results
+}
+
+func (p *Pool) resolveOne(ctx context.Context, job Job, perJobTimeout time.Duration) Result {
+	jobCtx := ctx
+	if perJobTimeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(ctx, perJobTimeout)
+		defer cancel()
+	}
+
+	if err := jobCtx.Err(); err != nil {
+		return Result{Index: job.Index, Err: &api.AttractionError{Code: api.ErrCodeGeocodeCancelled, Message: err.Error()}}
+	}
+
+	if p.limiter != nil {
+		if err := p.limiter.Wait(jobCtx); err != nil {
+			if jobCtx.Err() == context.DeadlineExceeded {
+				return Result{Index: job.Index, Err: &api.AttractionError{Code: api.ErrCodeGeocodeTimeout, Message: "timed out waiting for rate limiter"}}
+			}
+
+			return Result{Index: job.Index, Err: &api.AttractionError{Code: api.ErrCodeGeocodeRateLimited, Message: err.Error()}}
+		}
+	}
+
+	location, err := p.resolver.Resolve(job.Query)
+	if err != nil {
+		return Result{Index: job.Index, Err: &api.AttractionError{Code: api.ErrCodeGeocodeFailed, Message: err.Error()}}
+	}
+
+	return Result{Index: job.Index, Location: location}
+}
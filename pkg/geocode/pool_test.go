@@ -0,0 +1,64 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeResolver is a Resolver stand-in for tests, avoiding any real OSM network calls.
+type fakeResolver struct {
+	locations map[string]*Location
+}
+
+func (f *fakeResolver) Resolve(query Query) (*Location, error) {
+	location, ok := f.locations[query.Address]
+	if !ok {
+		return nil, ErrNoMatch
+	}
+
+	return location, nil
+}
+
+func TestPoolResolvesAllQueriesConcurrently(t *testing.T) {
+	resolver := &fakeResolver{locations: map[string]*Location{
+		"Eiffel Tower":      {Lat: 48.8584, Lng: 2.2945},
+		"Statue of Liberty": {Lat: 40.6892, Lng: -74.0445},
+	}}
+
+	pool := NewPool(resolver, 4, nil)
+	results := pool.Resolve(context.Background(), []Query{
+		{Address: "Eiffel Tower"},
+		{Address: "Statue of Liberty"},
+		{Address: "Nowhere"},
+	}, time.Second)
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].Location == nil || results[0].Location.Lat != 48.8584 {
+		t.Errorf("expected Eiffel Tower to resolve, got %+v", results[0])
+	}
+	if results[1].Err != nil || results[1].Location == nil || results[1].Location.Lng != -74.0445 {
+		t.Errorf("expected Statue of Liberty to resolve, got %+v", results[1])
+	}
+	if results[2].Err == nil {
+		t.Errorf("expected Nowhere to fail")
+	}
+}
+
+func TestPoolRespectsContextCancellation(t *testing.T) {
+	resolver := &fakeResolver{locations: map[string]*Location{"A": {Lat: 1, Lng: 1}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pool := NewPool(resolver, 2, nil)
+	results := pool.Resolve(ctx, []Query{{Address: "A"}, {Address: "B"}}, time.Second)
+
+	for i, result := range results {
+		if result.Err == nil {
+			t.Errorf("result %d: expected cancelled context to produce an error, got %+v", i, result)
+		}
+	}
+}
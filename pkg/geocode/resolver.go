@@ -0,0 +1,55 @@
+// Package geocode resolves locations for attractions that a precise geocoder couldn't handle on
+// its own. Resolvers compose into a chain of responsibility (see ChainResolver): each is tried in
+// order and the first to succeed wins.
+package geocode
+
+import "errors"
+
+// Location is the resolved coordinate pair a Resolver produces.
+type Location struct {
+	Lat float64
+	Lng float64
+}
+
+// Query is what a Resolver attempts to resolve: free-text (an address or city name) plus,
+// optionally, the requesting client's IP for resolvers that fall back to GeoIP lookup.
+type Query struct {
+	Address  string
+	ClientIP string
+}
+
+// Resolver resolves a Query into a Location, or returns an error if it can't.
+type Resolver interface {
+	Resolve(query Query) (*Location, error)
+}
+
+// ErrNoMatch is returned by a Resolver when it has nothing to offer for the given Query.
+var ErrNoMatch = errors.New("geocode: no match for query")
+
+// ChainResolver tries each Resolver in order, returning the first successful Location. This is
+// how a fuzzy city-name match and a GeoIP lookup get a second (and third) chance at an attraction
+// that a precise geocoder failed on.
+type ChainResolver struct {
+	resolvers []Resolver
+}
+
+// NewChainResolver returns a ChainResolver that tries resolvers in the given order.
+func NewChainResolver(resolvers ...Resolver) *ChainResolver {
+	return &ChainResolver{resolvers: resolvers}
+}
+
+// Resolve tries each resolver in order and returns the first successful Location.
+func (c *ChainResolver) Resolve(query Query) (*Location, error) {
+	lastErr := ErrNoMatch
+	for _, resolver := range c.resolvers {
+		location, err := resolver.Resolve(query)
+		if err == nil && location != nil {
+			return location, nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,184 @@
+// Package graph provides a small node/neighbourhood graph, modeled after the node/neighbourhood
+// pattern used in go-micro's network package, for computing the 1-medoid of a set of
+// neighborhoods. The Graph is safe for concurrent use so a single cached instance can be shared
+// across HTTP requests.
+package graph
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+// Neighbourhood is the minimal geographic/identity data a graph Node carries. It mirrors
+// api.Neighborhood's shape without importing pkg/api, since pkg/api is the one that builds and
+// queries graphs (importing it back the other way would cycle).
+type Neighbourhood struct {
+	Name                string
+	City                string
+	StateOrProvinceName string
+	Country             string
+	Latitude            float64
+	Longitude           float64
+}
+
+// Node is a single vertex in the graph.
+type Node struct {
+	Neighbourhood
+	graph *Graph
+}
+
+// Neighbours returns the nodes directly connected to this one in its owning graph.
+func (n Node) Neighbours() []Node {
+	if n.graph == nil {
+		return nil
+	}
+
+	return n.graph.neighboursOf(n.Name)
+}
+
+// Edge denotes a weighted connection from one node to another.
+type Edge struct {
+	Source           Node
+	Target           Node
+	DistanceInMeters float64
+}
+
+// Graph stores nodes and the edges between them, guarded by a RWMutex so multiple concurrent
+// HTTP requests can share and mutate a cached graph safely.
+type Graph struct {
+	mu    sync.RWMutex
+	nodes []Node
+	edges map[string][]Edge
+}
+
+// NewGraph returns an empty, ready-to-use Graph.
+func NewGraph() *Graph {
+	return &Graph{edges: make(map[string][]Edge)}
+}
+
+// BuildGraph constructs a fully-connected graph over the given neighbourhoods, using distanceFn
+// to weight each edge. Callers typically pass a distance function backed by a cache so repeated
+// pairs don't recompute their distance.
+func BuildGraph(neighbourhoods []Neighbourhood, distanceFn func(a Neighbourhood, b Neighbourhood) float64) *Graph {
+	g := NewGraph()
+
+	nodes := make([]Node, len(neighbourhoods))
+	for i, neighbourhood := range neighbourhoods {
+		nodes[i] = Node{Neighbourhood: neighbourhood, graph: g}
+	}
+
+	g.mu.Lock()
+	g.nodes = nodes
+	for _, source := range nodes {
+		for _, target := range nodes {
+			if source.Name == target.Name {
+				continue
+			}
+
+			distanceInMeters := distanceFn(source.Neighbourhood, target.Neighbourhood)
+			g.edges[source.Name] = append(g.edges[source.Name], Edge{source, target, distanceInMeters})
+		}
+	}
+	g.mu.Unlock()
+
+	return g
+}
+
+func (g *Graph) neighboursOf(nodeName string) []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	edges := g.edges[nodeName]
+	nodes := make([]Node, len(edges))
+	for i, edge := range edges {
+		nodes[i] = edge.Target
+	}
+
+	return nodes
+}
+
+// Nodes returns a snapshot of the graph's nodes.
+func (g *Graph) Nodes() []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	nodes := make([]Node, len(g.nodes))
+	copy(nodes, g.nodes)
+
+	return nodes
+}
+
+// ErrEmptyGraph indicates FindMedoid was called against a graph with no nodes.
+var ErrEmptyGraph = errors.New("graph has no nodes")
+
+// DistanceMetric aggregates a node's distances to every other node into a single score that
+// FindMedoid minimizes.
+type DistanceMetric func(distancesInMeters []float64) float64
+
+// SumDistance minimizes the total distance to all other nodes. This is the repo's original,
+// default notion of "best".
+func SumDistance(distancesInMeters []float64) float64 {
+	var sum float64
+	for _, distance := range distancesInMeters {
+		sum += distance
+	}
+
+	return sum
+}
+
+// SumOfSquaresDistance penalizes outlier distances more heavily than SumDistance.
+func SumOfSquaresDistance(distancesInMeters []float64) float64 {
+	var sum float64
+	for _, distance := range distancesInMeters {
+		sum += distance * distance
+	}
+
+	return sum
+}
+
+// MaxDistance minimizes the worst-case (farthest) distance to any other node.
+func MaxDistance(distancesInMeters []float64) float64 {
+	var max float64
+	for _, distance := range distancesInMeters {
+		if distance > max {
+			max = distance
+		}
+	}
+
+	return max
+}
+
+// FindMedoid returns the node minimizing metric's aggregate distance to every other node in the
+// graph -- the 1-medoid. Pass SumDistance for the repo's historical behavior, or
+// SumOfSquaresDistance / MaxDistance to tune what "best" means.
+func FindMedoid(g *Graph, metric DistanceMetric) (Node, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if len(g.nodes) == 0 {
+		return Node{}, ErrEmptyGraph
+	}
+
+	if len(g.nodes) == 1 {
+		return g.nodes[0], nil
+	}
+
+	bestScore := math.Inf(1)
+	var best Node
+	for _, node := range g.nodes {
+		edges := g.edges[node.Name]
+		distances := make([]float64, len(edges))
+		for i, edge := range edges {
+			distances[i] = edge.DistanceInMeters
+		}
+
+		score := metric(distances)
+		if score < bestScore {
+			bestScore = score
+			best = node
+		}
+	}
+
+	return best, nil
+}
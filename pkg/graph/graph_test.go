@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+)
+
+// linePoints lays out four neighbourhoods on a number line (so "distance" is just the absolute
+// difference), which makes the expected medoid for each metric easy to reason about by hand.
+func linePoints() []Neighbourhood {
+	return []Neighbourhood{
+		{Name: "A", Latitude: 0},
+		{Name: "B", Latitude: 1},
+		{Name: "C", Latitude: 2},
+		{Name: "D", Latitude: 10},
+	}
+}
+
+func lineDistance(a Neighbourhood, b Neighbourhood) float64 {
+	d := a.Latitude - b.Latitude
+	if d < 0 {
+		d = -d
+	}
+	return d
+}
+
+func TestBuildGraphConnectsEveryPairExceptSelf(t *testing.T) {
+	g := BuildGraph(linePoints(), lineDistance)
+
+	nodes := g.Nodes()
+	if len(nodes) != 4 {
+		t.Fatalf("expected 4 nodes, got %d", len(nodes))
+	}
+
+	for _, node := range nodes {
+		neighbours := node.Neighbours()
+		if len(neighbours) != 3 {
+			t.Errorf("node %s: expected 3 neighbours, got %d", node.Name, len(neighbours))
+		}
+		for _, neighbour := range neighbours {
+			if neighbour.Name == node.Name {
+				t.Errorf("node %s: should not be its own neighbour", node.Name)
+			}
+		}
+	}
+}
+
+func TestFindMedoidSumDistancePicksMiddlePoint(t *testing.T) {
+	g := BuildGraph(linePoints(), lineDistance)
+
+	medoid, err := FindMedoid(g, SumDistance)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := bruteForceMedoidName(linePoints(), lineDistance, SumDistance)
+	if medoid.Name != want {
+		t.Errorf("expected medoid %s, got %s", want, medoid.Name)
+	}
+}
+
+func TestFindMedoidSumOfSquaresPenalizesOutliers(t *testing.T) {
+	g := BuildGraph(linePoints(), lineDistance)
+
+	medoid, err := FindMedoid(g, SumOfSquaresDistance)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := bruteForceMedoidName(linePoints(), lineDistance, SumOfSquaresDistance)
+	if medoid.Name != want {
+		t.Errorf("expected medoid %s, got %s", want, medoid.Name)
+	}
+}
+
+func TestFindMedoidMaxDistanceMinimizesWorstCase(t *testing.T) {
+	g := BuildGraph(linePoints(), lineDistance)
+
+	medoid, err := FindMedoid(g, MaxDistance)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := bruteForceMedoidName(linePoints(), lineDistance, MaxDistance)
+	if medoid.Name != want {
+		t.Errorf("expected medoid %s, got %s", want, medoid.Name)
+	}
+}
+
+func TestFindMedoidEmptyGraphReturnsErrEmptyGraph(t *testing.T) {
+	g := NewGraph()
+
+	_, err := FindMedoid(g, SumDistance)
+	if !errors.Is(err, ErrEmptyGraph) {
+		t.Fatalf("expected ErrEmptyGraph, got %v", err)
+	}
+}
+
+func TestFindMedoidSingletonGraphReturnsTheOnlyNode(t *testing.T) {
+	g := BuildGraph([]Neighbourhood{{Name: "Solo"}}, lineDistance)
+
+	medoid, err := FindMedoid(g, SumDistance)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if medoid.Name != "Solo" {
+		t.Errorf("expected Solo, got %s", medoid.Name)
+	}
+}
+
+// bruteForceMedoidName recomputes the expected medoid directly from the point set, independent of
+// Graph/FindMedoid, so the tests above aren't just checking FindMedoid against itself.
+func bruteForceMedoidName(neighbourhoods []Neighbourhood, distanceFn func(a, b Neighbourhood) float64, metric DistanceMetric) string {
+	bestScore := -1.0
+	best := ""
+	for _, candidate := range neighbourhoods {
+		var distances []float64
+		for _, other := range neighbourhoods {
+			if other.Name == candidate.Name {
+				continue
+			}
+			distances = append(distances, distanceFn(candidate, other))
+		}
+
+		score := metric(distances)
+		if best == "" || score < bestScore {
+			bestScore = score
+			best = candidate.Name
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,248 @@
+// Package spatial provides an in-memory spatial index over geographic points so that
+// nearest-neighbor style queries don't have to round-trip to PostGIS for every lookup.
+package spatial
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// earthRadiusMeters is the mean radius used for ECEF conversion and great-circle distance.
+// Good enough for neighborhood-scale queries; we don't need WGS84 ellipsoid precision here.
+const earthRadiusMeters = 6371000.0
+
+// IndexedPoint is the minimal geographic record the tree stores and returns. It intentionally
+// mirrors api.Neighborhood's fields rather than importing pkg/api, since pkg/api is the one
+// that builds and queries the tree (importing it back the other way would cycle).
+type IndexedPoint struct {
+	Name                string
+	City                string
+	StateOrProvinceName string
+	Country             string
+	Latitude            float64
+	Longitude           float64
+}
+
+// toECEF converts a lat/lng (in degrees) to earth-centered, earth-fixed x/y/z coordinates so
+// that Euclidean nearest-neighbor in the tree corresponds to great-circle nearest neighbor on
+// the sphere.
+func toECEF(lat float64, lng float64) (x float64, y float64, z float64) {
+	latRad := lat * math.Pi / 180
+	lngRad := lng * math.Pi / 180
+
+	x = earthRadiusMeters * math.Cos(latRad) * math.Cos(lngRad)
+	y = earthRadiusMeters * math.Cos(latRad) * math.Sin(lngRad)
+	z = earthRadiusMeters * math.Sin(latRad)
+
+	return x, y, z
+}
+
+// GreatCircleDistanceMeters returns the haversine distance, in meters, between two lat/lng
+// points. It exists alongside the ECEF tree so callers can verify PostGIS agreement, or avoid
+// a PostGIS round-trip entirely, without reimplementing the formula at each call site.
+func GreatCircleDistanceMeters(lat1 float64, lng1 float64, lat2 float64, lng2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLat := (lat2 - lat1) * math.Pi / 180
+	deltaLng := (lng2 - lng1) * math.Pi / 180
+
+	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*math.Sin(deltaLng/2)*math.Sin(deltaLng/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return earthRadiusMeters * c
+}
+
+type kdNode struct {
+	point       IndexedPoint
+	x, y, z     float64
+	left, right *kdNode
+}
+
+// KDTree is a 3-dimensional k-d tree over neighborhood centroids, keyed on ECEF coordinates.
+type KDTree struct {
+	root *kdNode
+	size int
+}
+
+// NewKDTree builds a balanced k-d tree from the given points. Intended to be built once from
+// PostGIS at startup (optionally rebuilt on a TTL), not per-request.
+func NewKDTree(points []IndexedPoint) *KDTree {
+	nodes := make([]*kdNode, len(points))
+	for i, point := range points {
+		x, y, z := toECEF(point.Latitude, point.Longitude)
+		nodes[i] = &kdNode{point: point, x: x, y: y, z: z}
+	}
+
+	return &KDTree{root: buildKDNode(nodes, 0), size: len(nodes)}
+}
+
+func buildKDNode(nodes []*kdNode, depth int) *kdNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	axis := depth % 3
+	sort.Slice(nodes, func(i, j int) bool {
+		return axisValue(nodes[i], axis) < axisValue(nodes[j], axis)
+	})
+
+	mid := len(nodes) / 2
+	node := nodes[mid]
+	node.left = buildKDNode(nodes[:mid], depth+1)
+	node.right = buildKDNode(nodes[mid+1:], depth+1)
+
+	return node
+}
+
+func axisValue(node *kdNode, axis int) float64 {
+	switch axis {
+	case 0:
+		return node.x
+	case 1:
+		return node.y
+	default:
+		return node.z
+	}
+}
+
+func squaredDistance(x1, y1, z1, x2, y2, z2 float64) float64 {
+	dx := x1 - x2
+	dy := y1 - y2
+	dz := z1 - z2
+
+	return dx*dx + dy*dy + dz*dz
+}
+
+// neighborCandidate is an IndexedPoint paired with its squared ECEF distance from the query
+// point, ordered so the farthest candidate sorts first (for a bounded max-heap of size k).
+type neighborCandidate struct {
+	point      IndexedPoint
+	sqDistance float64
+}
+
+type neighborCandidateMaxHeap []neighborCandidate
+
+func (h neighborCandidateMaxHeap) Len() int            { return len(h) }
+func (h neighborCandidateMaxHeap) Less(i, j int) bool  { return h[i].sqDistance > h[j].sqDistance }
+func (h neighborCandidateMaxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighborCandidateMaxHeap) Push(x interface{}) { *h = append(*h, x.(neighborCandidate)) }
+func (h *neighborCandidateMaxHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+// NearestN returns the k nearest indexed points to the given lat/lng, closest first.
+func (t *KDTree) NearestN(lat float64, lng float64, k int) []IndexedPoint {
+	if t.root == nil || k <= 0 {
+		return []IndexedPoint{}
+	}
+
+	x, y, z := toECEF(lat, lng)
+	h := &neighborCandidateMaxHeap{}
+	heap.Init(h)
+
+	searchNearest(t.root, x, y, z, 0, k, h)
+
+	result := make([]IndexedPoint, h.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(h).(neighborCandidate).point
+	}
+
+	return result
+}
+
+func searchNearest(node *kdNode, x, y, z float64, depth int, k int, h *neighborCandidateMaxHeap) {
+	if node == nil {
+		return
+	}
+
+	sqDist := squaredDistance(x, y, z, node.x, node.y, node.z)
+	if h.Len() < k {
+		heap.Push(h, neighborCandidate{node.point, sqDist})
+	} else if sqDist < (*h)[0].sqDistance {
+		heap.Pop(h)
+		heap.Push(h, neighborCandidate{node.point, sqDist})
+	}
+
+	axis := depth % 3
+	diff := axisValue(node, axis) - axisValueXYZ(x, y, z, axis)
+
+	near, far := node.right, node.left
+	if diff > 0 {
+		near, far = node.left, node.right
+	}
+
+	searchNearest(near, x, y, z, depth+1, k, h)
+
+	// Only descend into the far subtree if it could still contain a closer point than our
+	// current worst kept candidate.
+	if h.Len() < k || diff*diff < (*h)[0].sqDistance {
+		searchNearest(far, x, y, z, depth+1, k, h)
+	}
+}
+
+func axisValueXYZ(x, y, z float64, axis int) float64 {
+	switch axis {
+	case 0:
+		return x
+	case 1:
+		return y
+	default:
+		return z
+	}
+}
+
+// WithinRadius returns every indexed point within the given radius (in meters) of lat/lng.
+func (t *KDTree) WithinRadius(lat float64, lng float64, meters float64) []IndexedPoint {
+	if t.root == nil || meters <= 0 {
+		return []IndexedPoint{}
+	}
+
+	x, y, z := toECEF(lat, lng)
+
+	// Convert the great-circle radius to a chord length so it can be compared directly against
+	// squared ECEF distance.
+	centralAngle := meters / earthRadiusMeters
+	chord := 2 * earthRadiusMeters * math.Sin(centralAngle/2)
+	sqChord := chord * chord
+
+	var result []IndexedPoint
+	searchRadius(t.root, x, y, z, 0, sqChord, &result)
+
+	return result
+}
+
+func searchRadius(node *kdNode, x, y, z float64, depth int, sqRadius float64, result *[]IndexedPoint) {
+	if node == nil {
+		return
+	}
+
+	if squaredDistance(x, y, z, node.x, node.y, node.z) <= sqRadius {
+		*result = append(*result, node.point)
+	}
+
+	axis := depth % 3
+	diff := axisValue(node, axis) - axisValueXYZ(x, y, z, axis)
+
+	near, far := node.right, node.left
+	if diff > 0 {
+		near, far = node.left, node.right
+	}
+
+	searchRadius(near, x, y, z, depth+1, sqRadius, result)
+
+	// Only descend into the far subtree if it could still contain a point within radius.
+	if diff*diff <= sqRadius {
+		searchRadius(far, x, y, z, depth+1, sqRadius, result)
+	}
+}
+
+// Size returns the number of points indexed by the tree.
+func (t *KDTree) Size() int {
+	return t.size
+}
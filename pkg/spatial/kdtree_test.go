@@ -0,0 +1,156 @@
+package spatial
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// epsilonMeters is the maximum acceptable disagreement between the tree's ECEF-based search and
+// a brute-force haversine scan over the same points.
+const epsilonMeters = 1.0
+
+func samplePoints() []IndexedPoint {
+	return []IndexedPoint{
+		{Name: "Downtown", Latitude: 40.7128, Longitude: -74.0060},
+		{Name: "Southside", Latitude: 40.6892, Longitude: -74.0445},
+		{Name: "East Bay", Latitude: 37.8044, Longitude: -122.2712},
+		{Name: "Capitol Hill", Latitude: 47.6062, Longitude: -122.3321},
+		{Name: "Back Bay", Latitude: 42.3505, Longitude: -71.0810},
+	}
+}
+
+// bruteForceNearest mirrors NearestN using a plain O(n²) haversine sort, so we can confirm
+// the k-d tree agrees with it rather than trusting the tree in isolation.
+func bruteForceNearest(points []IndexedPoint, lat float64, lng float64, k int) []IndexedPoint {
+	type scored struct {
+		point    IndexedPoint
+		distance float64
+	}
+
+	scoredPoints := make([]scored, len(points))
+	for i, point := range points {
+		scoredPoints[i] = scored{point, GreatCircleDistanceMeters(lat, lng, point.Latitude, point.Longitude)}
+	}
+
+	for i := 0; i < len(scoredPoints); i++ {
+		for j := i + 1; j < len(scoredPoints); j++ {
+			if scoredPoints[j].distance < scoredPoints[i].distance {
+				scoredPoints[i], scoredPoints[j] = scoredPoints[j], scoredPoints[i]
+			}
+		}
+	}
+
+	if k > len(scoredPoints) {
+		k = len(scoredPoints)
+	}
+
+	result := make([]IndexedPoint, k)
+	for i := 0; i < k; i++ {
+		result[i] = scoredPoints[i].point
+	}
+
+	return result
+}
+
+func TestNearestNAgreesWithBruteForce(t *testing.T) {
+	points := samplePoints()
+	tree := NewKDTree(points)
+
+	queryLat, queryLng := 40.7306, -73.9866 // roughly Manhattan
+
+	got := tree.NearestN(queryLat, queryLng, 3)
+	want := bruteForceNearest(points, queryLat, queryLng, 3)
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d neighbors, got %d", len(want), len(got))
+	}
+
+	for i := range want {
+		if got[i].Name != want[i].Name {
+			t.Errorf("position %d: expected %s, got %s", i, want[i].Name, got[i].Name)
+		}
+	}
+}
+
+func TestWithinRadiusAgreesWithBruteForce(t *testing.T) {
+	points := samplePoints()
+	tree := NewKDTree(points)
+
+	queryLat, queryLng := 40.7128, -74.0060 // Downtown itself
+	radiusMeters := 10000.0
+
+	got := tree.WithinRadius(queryLat, queryLng, radiusMeters)
+
+	gotNames := make(map[string]bool)
+	for _, point := range got {
+		gotNames[point.Name] = true
+	}
+
+	for _, point := range points {
+		distance := GreatCircleDistanceMeters(queryLat, queryLng, point.Latitude, point.Longitude)
+		withinBruteForce := distance <= radiusMeters+epsilonMeters
+
+		if withinBruteForce != gotNames[point.Name] {
+			t.Errorf("%s: brute-force within-radius=%v, tree within-radius=%v (distance=%.2fm)", point.Name, withinBruteForce, gotNames[point.Name], distance)
+		}
+	}
+}
+
+// TestWithinRadiusAgreesWithBruteForceRandomized exercises searchRadius over a much larger,
+// randomly-generated point set and a range of radii so that both the left and right subtree of
+// every split gets a chance to actually contain the query point. The small, shallow tree in
+// TestWithinRadiusAgreesWithBruteForce isn't enough to catch a pruning bug that only shows up
+// once the query point falls on the "right" side of a split.
+func TestWithinRadiusAgreesWithBruteForceRandomized(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	points := make([]IndexedPoint, 300)
+	for i := range points {
+		points[i] = IndexedPoint{
+			Name:      randPointName(i),
+			Latitude:  rng.Float64()*180 - 90,
+			Longitude: rng.Float64()*360 - 180,
+		}
+	}
+	tree := NewKDTree(points)
+
+	for trial := 0; trial < 200; trial++ {
+		queryLat := rng.Float64()*180 - 90
+		queryLng := rng.Float64()*360 - 180
+		radiusMeters := 500000.0 + rng.Float64()*1500000.0 // 500km-2000km
+
+		got := tree.WithinRadius(queryLat, queryLng, radiusMeters)
+		gotNames := make(map[string]bool, len(got))
+		for _, point := range got {
+			gotNames[point.Name] = true
+		}
+
+		for _, point := range points {
+			distance := GreatCircleDistanceMeters(queryLat, queryLng, point.Latitude, point.Longitude)
+			withinBruteForce := distance <= radiusMeters+epsilonMeters
+
+			if withinBruteForce != gotNames[point.Name] {
+				t.Fatalf("trial %d: %s: brute-force within-radius=%v, tree within-radius=%v (distance=%.2fm, radius=%.2fm)",
+					trial, point.Name, withinBruteForce, gotNames[point.Name], distance, radiusMeters)
+			}
+		}
+	}
+}
+
+func randPointName(i int) string {
+	return "point-" + strconv.Itoa(i)
+}
+
+func TestGreatCircleDistanceMetersIsSymmetric(t *testing.T) {
+	a := IndexedPoint{Latitude: 40.7128, Longitude: -74.0060}
+	b := IndexedPoint{Latitude: 37.8044, Longitude: -122.2712}
+
+	forward := GreatCircleDistanceMeters(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+	backward := GreatCircleDistanceMeters(b.Latitude, b.Longitude, a.Latitude, a.Longitude)
+
+	if math.Abs(forward-backward) > epsilonMeters {
+		t.Errorf("expected symmetric distance, got %.2fm vs %.2fm", forward, backward)
+	}
+}
@@ -0,0 +1,43 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// statusResponse is the payload for GET /status: service-wide counters. TotalRequests counts
+// published Events, one per attraction processed, not HTTP requests.
+type statusResponse struct {
+	TotalRequests       int     `json:"total_requests"`
+	FailedGeocodes      int     `json:"failed_geocodes"`
+	PostGISLatencyP50Ms float64 `json:"postgis_latency_p50_ms"`
+	PostGISLatencyP95Ms float64 `json:"postgis_latency_p95_ms"`
+}
+
+// neighborhoodsResponse is the payload for GET /status/neighborhoods.
+type neighborhoodsResponse struct {
+	Neighborhoods []NeighborhoodStatus `json:"neighborhoods"`
+}
+
+// Handler serves GET /status with service-wide counters.
+func (t *Tracker) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		totalRequests, failedGeocodes, p50Millis, p95Millis := t.serviceSnapshot()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(statusResponse{
+			TotalRequests:       totalRequests,
+			FailedGeocodes:      failedGeocodes,
+			PostGISLatencyP50Ms: p50Millis,
+			PostGISLatencyP95Ms: p95Millis,
+		})
+	}
+}
+
+// NeighborhoodsHandler serves GET /status/neighborhoods with per-neighborhood coverage stats.
+func (t *Tracker) NeighborhoodsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(neighborhoodsResponse{Neighborhoods: t.NeighborhoodsSnapshot()})
+	}
+}
@@ -0,0 +1,172 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// seedFakeAttractions publishes events as if two attractions resolved into "Downtown" and one
+// failed to geocode, simulating what the /attractions handler would push per request.
+func seedFakeAttractions(t *Tracker) {
+	now := time.Now()
+
+	// The failed geocode is published first so that, once Downtown shows both attractions, every
+	// seeded event has been applied (the tracker consumes events in order).
+	t.Publish(Event{GeocodeSucceeded: false, OccurredAt: now})
+	t.Publish(Event{NeighborhoodName: "Downtown", NeighborhoodMatched: true, DistanceMeters: 100, GeocodeSucceeded: true, OccurredAt: now})
+	t.Publish(Event{NeighborhoodName: "Downtown", NeighborhoodMatched: true, DistanceMeters: 300, GeocodeSucceeded: true, OccurredAt: now})
+}
+
+// waitForAttractionCount polls the tracker's snapshot until Downtown shows the expected
+// attraction count or the deadline passes, since events are folded in asynchronously.
+func waitForAttractionCount(t *Tracker, name string, want int, deadline time.Duration) bool {
+	cutoff := time.Now().Add(deadline)
+	for time.Now().Before(cutoff) {
+		for _, n := range t.NeighborhoodsSnapshot() {
+			if n.Name == name && n.AttractionCount == want {
+				return true
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return false
+}
+
+// waitForGeocodeSuccessRatio polls the tracker's snapshot until the named neighborhood shows the
+// expected ratio or the deadline passes. Used instead of waitForAttractionCount when the events
+// being waited on (e.g. near-miss failures) don't move AttractionCount.
+func waitForGeocodeSuccessRatio(t *Tracker, name string, want float64, deadline time.Duration) bool {
+	cutoff := time.Now().Add(deadline)
+	for time.Now().Before(cutoff) {
+		for _, n := range t.NeighborhoodsSnapshot() {
+			if n.Name == name && n.GeocodeSuccessRatio == want {
+				return true
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return false
+}
+
+func TestNeighborhoodsHandlerReportsSeededAttractions(t *testing.T) {
+	tracker := NewTracker(16)
+	seedFakeAttractions(tracker)
+
+	if !waitForAttractionCount(tracker, "Downtown", 2, 100*time.Millisecond) {
+		t.Fatal("timed out waiting for seeded events to be processed")
+	}
+
+	server := httptest.NewServer(tracker.NeighborhoodsHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error calling handler: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body neighborhoodsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("unexpected error decoding response: %v", err)
+	}
+
+	if len(body.Neighborhoods) != 1 {
+		t.Fatalf("expected 1 neighborhood, got %d", len(body.Neighborhoods))
+	}
+
+	downtown := body.Neighborhoods[0]
+	if downtown.Name != "Downtown" {
+		t.Errorf("expected Downtown, got %s", downtown.Name)
+	}
+	if downtown.AttractionCount != 2 {
+		t.Errorf("expected attraction count 2, got %d", downtown.AttractionCount)
+	}
+	if downtown.AverageDistanceMeters != 200 {
+		t.Errorf("expected average distance 200, got %f", downtown.AverageDistanceMeters)
+	}
+	if downtown.GeocodeSuccessRatio != 1 {
+		t.Errorf("expected geocode success ratio 1, got %f", downtown.GeocodeSuccessRatio)
+	}
+}
+
+// TestNeighborhoodsHandlerReflectsNearMissFailures confirms GeocodeSuccessRatio actually moves
+// when a neighborhood has been attributed a PostGIS-resolution failure (NeighborhoodMatched:
+// false), not just successes -- the ratio was previously structurally incapable of being anything
+// but 1.0 because nothing ever published a failure with a neighborhood name attached. It also
+// confirms the near-miss event does NOT inflate AttractionCount/AverageDistanceMeters, which only
+// ever actually-resolved attractions should count toward.
+func TestNeighborhoodsHandlerReflectsNearMissFailures(t *testing.T) {
+	tracker := NewTracker(16)
+	now := time.Now()
+
+	tracker.Publish(Event{NeighborhoodName: "Southside", NeighborhoodMatched: true, DistanceMeters: 50, GeocodeSucceeded: true, OccurredAt: now})
+	tracker.Publish(Event{NeighborhoodName: "Southside", NeighborhoodMatched: false, GeocodeSucceeded: true, OccurredAt: now})
+
+	if !waitForGeocodeSuccessRatio(tracker, "Southside", 0.5, 100*time.Millisecond) {
+		t.Fatal("timed out waiting for seeded events to be processed")
+	}
+
+	server := httptest.NewServer(tracker.NeighborhoodsHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error calling handler: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body neighborhoodsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("unexpected error decoding response: %v", err)
+	}
+
+	if len(body.Neighborhoods) != 1 {
+		t.Fatalf("expected 1 neighborhood, got %d", len(body.Neighborhoods))
+	}
+
+	southside := body.Neighborhoods[0]
+	if southside.AttractionCount != 1 {
+		t.Errorf("expected attraction count 1 (the near-miss should not count), got %d", southside.AttractionCount)
+	}
+	if southside.AverageDistanceMeters != 50 {
+		t.Errorf("expected average distance 50 (unaffected by the near-miss's zero distance), got %f", southside.AverageDistanceMeters)
+	}
+	if southside.GeocodeSuccessRatio != 0.5 {
+		t.Errorf("expected geocode success ratio 0.5, got %f", southside.GeocodeSuccessRatio)
+	}
+}
+
+func TestStatusHandlerReportsFailedGeocodes(t *testing.T) {
+	tracker := NewTracker(16)
+	seedFakeAttractions(tracker)
+
+	if !waitForAttractionCount(tracker, "Downtown", 2, 100*time.Millisecond) {
+		t.Fatal("timed out waiting for seeded events to be processed")
+	}
+
+	server := httptest.NewServer(tracker.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error calling handler: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body statusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("unexpected error decoding response: %v", err)
+	}
+
+	if body.TotalRequests != 3 {
+		t.Errorf("expected total requests 3, got %d", body.TotalRequests)
+	}
+	if body.FailedGeocodes != 1 {
+		t.Errorf("expected failed geocodes 1, got %d", body.FailedGeocodes)
+	}
+}
@@ -0,0 +1,168 @@
+// Package status collects reserve-style health and coverage stats for neighborhoods this service
+// has matched, plus service-wide counters, without slowing down the request path: callers publish
+// Events onto a buffered channel and a single background goroutine folds them into the running
+// totals.
+package status
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event is published by the HTTP handler once per attraction, after geocoding and neighborhood
+// resolution have been attempted. NeighborhoodName may be set even when NeighborhoodMatched is
+// false: when PostGIS fails to resolve a containing neighborhood, the handler still attributes
+// the failure to the nearest candidate centroid so per-neighborhood GeocodeSuccessRatio reflects
+// near-miss failures instead of only ever seeing successes.
+type Event struct {
+	NeighborhoodName    string
+	NeighborhoodMatched bool
+	DistanceMeters      float64
+	GeocodeSucceeded    bool
+	PostGISLatency      time.Duration
+	OccurredAt          time.Time
+}
+
+type neighborhoodStats struct {
+	attractionCount     int
+	totalDistanceMeters float64
+	lastSeen            time.Time
+	matchSuccesses      int
+	matchFailures       int
+}
+
+// Tracker accumulates Events asynchronously. The zero value is not usable; construct one with
+// NewTracker.
+type Tracker struct {
+	events chan Event
+
+	mu               sync.RWMutex
+	neighborhoods    map[string]*neighborhoodStats
+	totalRequests    int
+	failedGeocodes   int
+	postGISLatencies []time.Duration
+}
+
+// NewTracker starts a Tracker backed by a channel of the given buffer size and begins consuming
+// events in a background goroutine. A larger buffer absorbs bursts of traffic without dropping
+// events.
+func NewTracker(bufferSize int) *Tracker {
+	t := &Tracker{
+		events:        make(chan Event, bufferSize),
+		neighborhoods: make(map[string]*neighborhoodStats),
+	}
+
+	go t.consume()
+
+	return t
+}
+
+// Publish enqueues event for asynchronous processing. If the buffer is full the event is dropped
+// rather than blocking the caller -- status collection must never slow down /attractions.
+func (t *Tracker) Publish(event Event) {
+	select {
+	case t.events <- event:
+	default:
+	}
+}
+
+func (t *Tracker) consume() {
+	for event := range t.events {
+		t.apply(event)
+	}
+}
+
+func (t *Tracker) apply(event Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.totalRequests++
+	if !event.GeocodeSucceeded {
+		t.failedGeocodes++
+	}
+	if event.PostGISLatency > 0 {
+		t.postGISLatencies = append(t.postGISLatencies, event.PostGISLatency)
+	}
+
+	if event.NeighborhoodName == "" {
+		return
+	}
+
+	stats, ok := t.neighborhoods[event.NeighborhoodName]
+	if !ok {
+		stats = &neighborhoodStats{}
+		t.neighborhoods[event.NeighborhoodName] = stats
+	}
+
+	stats.lastSeen = event.OccurredAt
+	if event.NeighborhoodMatched {
+		stats.attractionCount++
+		stats.totalDistanceMeters += event.DistanceMeters
+		stats.matchSuccesses++
+	} else {
+		stats.matchFailures++
+	}
+}
+
+// NeighborhoodStatus is the per-neighborhood snapshot reported by GET /status/neighborhoods.
+type NeighborhoodStatus struct {
+	Name                  string    `json:"name"`
+	AttractionCount       int       `json:"attraction_count"`
+	AverageDistanceMeters float64   `json:"average_distance_meters"`
+	LastSeen              time.Time `json:"last_seen"`
+	GeocodeSuccessRatio   float64   `json:"geocode_success_ratio"`
+}
+
+// NeighborhoodsSnapshot returns a NeighborhoodStatus for every neighborhood the tracker has ever
+// seen an attraction resolve into or been attributed a near-miss candidate for. AttractionCount
+// and AverageDistanceMeters only reflect attractions that actually resolved into the
+// neighborhood; GeocodeSuccessRatio also accounts for near-miss failures attributed to it.
+func (t *Tracker) NeighborhoodsSnapshot() []NeighborhoodStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	snapshot := make([]NeighborhoodStatus, 0, len(t.neighborhoods))
+	for name, stats := range t.neighborhoods {
+		var averageDistance float64
+		if stats.attractionCount > 0 {
+			averageDistance = stats.totalDistanceMeters / float64(stats.attractionCount)
+		}
+
+		var matchRatio float64
+		if total := stats.matchSuccesses + stats.matchFailures; total > 0 {
+			matchRatio = float64(stats.matchSuccesses) / float64(total)
+		}
+
+		snapshot = append(snapshot, NeighborhoodStatus{
+			Name:                  name,
+			AttractionCount:       stats.attractionCount,
+			AverageDistanceMeters: averageDistance,
+			LastSeen:              stats.lastSeen,
+			GeocodeSuccessRatio:   matchRatio,
+		})
+	}
+
+	return snapshot
+}
+
+func (t *Tracker) serviceSnapshot() (totalRequests int, failedGeocodes int, p50Millis float64, p95Millis float64) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.totalRequests, t.failedGeocodes, percentileMillis(t.postGISLatencies, 0.50), percentileMillis(t.postGISLatencies, 0.95)
+}
+
+func percentileMillis(latencies []time.Duration, p float64) float64 {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	idx := int(p * float64(len(sorted)-1))
+
+	return float64(sorted[idx]) / float64(time.Millisecond)
+}